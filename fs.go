@@ -0,0 +1,126 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2020 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+// DefaultFSExtensions are the filename extensions tried, in order, when an
+// FSProvider resolves a partial name to a file.
+var DefaultFSExtensions = []string{".mustache"}
+
+// FSProvider implements PartialProvider by reading partials from an
+// fs.FS, such as an os.DirFS or an embed.FS. A partial named "foo" is
+// resolved by joining Root with "foo" and trying each of Extensions in
+// turn. Contents read from the filesystem are cached under the partial
+// name; use Invalidate to drop a cached entry once its source changes.
+type FSProvider struct {
+	FS         fs.FS
+	Root       string
+	Extensions []string
+
+	mu    sync.RWMutex
+	cache map[string]string
+}
+
+// NewFSProvider returns an FSProvider rooted at root within fsys, trying
+// DefaultFSExtensions for each partial name.
+func NewFSProvider(fsys fs.FS, root string) *FSProvider {
+	return &FSProvider{FS: fsys, Root: root, Extensions: DefaultFSExtensions}
+}
+
+// Get accepts the name of a partial and returns its contents, reading it
+// from the underlying filesystem on first use and from the cache
+// afterwards.
+func (fp *FSProvider) Get(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &ErrPartialNotFound{name}
+	}
+
+	if data, ok := fp.cached(name); ok {
+		return data, nil
+	}
+
+	exts := fp.Extensions
+	if len(exts) == 0 {
+		exts = DefaultFSExtensions
+	}
+	for _, ext := range exts {
+		data, err := fs.ReadFile(fp.FS, path.Join(fp.Root, name+ext))
+		if err != nil {
+			continue
+		}
+		fp.store(name, string(data))
+		return string(data), nil
+	}
+	return "", &ErrPartialNotFound{name}
+}
+
+func (fp *FSProvider) cached(name string) (string, bool) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+	data, ok := fp.cache[name]
+	return data, ok
+}
+
+func (fp *FSProvider) store(name, data string) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if fp.cache == nil {
+		fp.cache = map[string]string{}
+	}
+	fp.cache[name] = data
+}
+
+// Invalidate drops any cached contents for name, forcing the next Get to
+// re-read it from the filesystem. This only affects FSProvider's own
+// read cache; a cache-enabled Template (see Template.EnableCache) keeps
+// its own parsed copy of the partial and does not observe this call. If
+// such a Template may already have rendered name, also call its
+// Template.InvalidatePartial(name) so the change takes effect there too.
+func (fp *FSProvider) Invalidate(name string) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	delete(fp.cache, name)
+}
+
+// ParseFS compiles the named template file from fsys, using the
+// directory containing it as the root for resolving any partials it
+// references.
+func ParseFS(fsys fs.FS, name string) (*Template, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStringPartials(string(data), NewFSProvider(fsys, path.Dir(name)))
+}
+
+// ParseGlobFS compiles the template file matched by pattern, analogous to
+// ParseFS but selecting the file via a glob pattern as in
+// text/template.ParseGlob. Exactly one file must match.
+func ParseGlobFS(fsys fs.FS, pattern string) (*Template, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	switch len(names) {
+	case 0:
+		return nil, fmt.Errorf("mustache: pattern matches no files: %q", pattern)
+	case 1:
+		return ParseFS(fsys, names[0])
+	default:
+		return nil, fmt.Errorf("mustache: pattern matches more than one file: %q", pattern)
+	}
+}
@@ -0,0 +1,109 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2020 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"tmpl/page.mustache":         {Data: []byte("Hello {{>partials/row}}!")},
+		"tmpl/partials/row.mustache": {Data: []byte("[{{name}}]")},
+		"outside/secret.mustache":    {Data: []byte("should not be reachable")},
+	}
+}
+
+func TestFSProviderHappyPath(t *testing.T) {
+	fp := NewFSProvider(testFS(), "tmpl/partials")
+	data, err := fp.Get("row")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data != "[{{name}}]" {
+		t.Errorf("got %q", data)
+	}
+}
+
+func TestFSProviderMissingFile(t *testing.T) {
+	fp := NewFSProvider(testFS(), "tmpl/partials")
+	_, err := fp.Get("nope")
+	var notFound *ErrPartialNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("got %v, want *ErrPartialNotFound", err)
+	}
+}
+
+func TestFSProviderExtensionFallback(t *testing.T) {
+	fp := NewFSProvider(testFS(), "tmpl/partials")
+	fp.Extensions = []string{".txt", ".mustache"}
+	data, err := fp.Get("row")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if data != "[{{name}}]" {
+		t.Errorf("got %q", data)
+	}
+}
+
+func TestFSProviderRejectsPathTraversal(t *testing.T) {
+	fp := NewFSProvider(testFS(), "tmpl/partials")
+	var notFound *ErrPartialNotFound
+	if _, err := fp.Get("../../outside/secret"); !errors.As(err, &notFound) {
+		t.Fatalf("got %v, want *ErrPartialNotFound", err)
+	}
+}
+
+func TestParseFS(t *testing.T) {
+	tmpl, err := ParseFS(testFS(), "tmpl/page.mustache")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	out, err := tmpl.Render(map[string]interface{}{"name": "x"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "Hello [x]!"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestParseGlobFS(t *testing.T) {
+	tmpl, err := ParseGlobFS(testFS(), "tmpl/*.mustache")
+	if err != nil {
+		t.Fatalf("ParseGlobFS: %v", err)
+	}
+	out, err := tmpl.Render(map[string]interface{}{"name": "y"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "Hello [y]!"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestParseGlobFSAmbiguous(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.mustache": {Data: []byte("a")},
+		"b.mustache": {Data: []byte("b")},
+	}
+	if _, err := ParseGlobFS(fsys, "*.mustache"); err == nil {
+		t.Fatal("expected an error for an ambiguous glob")
+	}
+}
+
+func TestParseGlobFSNoMatch(t *testing.T) {
+	if _, err := ParseGlobFS(testFS(), "tmpl/*.nope"); err == nil {
+		t.Fatal("expected an error when the glob matches nothing")
+	}
+}
@@ -21,6 +21,7 @@
 package template
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"html/template"
@@ -29,6 +30,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // A TagType represents the specific type of mustache tag that a Tag
@@ -47,7 +50,7 @@ const (
 // Skip all whitespaces apeared after these types of tags until end of line if
 // the line only contains a tag and whitespaces.
 const (
-	SkipWhitespaceTagTypes = "#^/<>=!"
+	SkipWhitespaceTagTypes = "#^/<>=!|"
 )
 
 func (t TagType) String() string {
@@ -88,6 +91,8 @@ type textElement struct {
 type varElement struct {
 	name string
 	raw  bool
+	fn   string
+	line int
 }
 
 type sectionElement struct {
@@ -95,6 +100,22 @@ type sectionElement struct {
 	inverted  bool
 	startline int
 	elems     []interface{}
+	altElems  []interface{} // body of a "{{|}}" separator tag, slice/array sections only
+	text      string        // unrendered source of the section body, for lambdas
+}
+
+// FuncMap maps a formatter/lambda name, as used in a tag like
+// "{{ name | fn }}" or "{{fn name}}", to a function that transforms the
+// looked-up value into its rendered string. The empty name "" is the
+// default formatter applied to plain variable tags such as "{{name}}".
+type FuncMap map[string]func(interface{}) (string, error)
+
+// defaultFormatter reproduces the escaping behaviour of a plain,
+// non-raw variable tag.
+func defaultFormatter(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	template.HTMLEscape(&buf, []byte(fmt.Sprint(v)))
+	return buf.String(), nil
 }
 
 type partialElement struct {
@@ -105,14 +126,17 @@ type partialElement struct {
 
 // Template represents a compiled mustache template
 type Template struct {
-	data    string
-	otag    string
-	ctag    string
-	p       int
-	curline int
-	elems   []interface{}
-	partial PartialProvider
-	errmiss bool // Error when variable is not found?
+	data        string
+	otag        string
+	ctag        string
+	p           int
+	curline     int
+	elems       []interface{}
+	partial     PartialProvider
+	missing     MissingKeyMode // nil means MissingKeyInvalid
+	funcs       FuncMap
+	cache       *partialCache // nil unless caching was enabled via EnableCache
+	methodCache sync.Map      // methodCacheKey -> int, memoizes method lookups
 }
 
 type parseError struct {
@@ -322,6 +346,19 @@ func (tmpl *Template) readTag(mayStandalone bool) (*tagReadingResult, error) {
 	}, nil
 }
 
+// parseVarTag splits a variable tag's content into the variable name and,
+// if present, the name of a registered formatter/lambda. Both the
+// "{{ name | fn }}" and "{{fn name}}" forms are recognized.
+func parseVarTag(tag string) (name, fn string) {
+	if idx := strings.IndexByte(tag, '|'); idx >= 0 {
+		return strings.TrimSpace(tag[:idx]), strings.TrimSpace(tag[idx+1:])
+	}
+	if fields := strings.Fields(tag); len(fields) == 2 {
+		return fields[1], fields[0]
+	}
+	return tag, ""
+}
+
 func (tmpl *Template) parsePartial(name, indent string) (*partialElement, error) {
 	return &partialElement{
 		name:   name,
@@ -330,7 +367,13 @@ func (tmpl *Template) parsePartial(name, indent string) (*partialElement, error)
 	}, nil
 }
 
-func (tmpl *Template) parseSection(section *sectionElement) error {
+// parseSection parses the body of a section up to (and consuming) its
+// matching closing tag. A bare "{{|}}" tag switches target, for the rest
+// of the body, from section.elems to section.altElems: the latter is
+// rendered between successive iterations of a slice/array section (see
+// renderSection).
+func (tmpl *Template) parseSection(section *sectionElement, bodyStart int) error {
+	target := &section.elems
 	for {
 		textResult, err := tmpl.readText()
 		text := textResult.text
@@ -342,8 +385,10 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 			return parseError{section.startline, "Section " + section.name + " has no closing tag"}
 		}
 
+		tagStart := tmpl.p - len(tmpl.otag)
+
 		// put text into an item
-		section.elems = append(section.elems, &textElement{[]byte(text)})
+		*target = append(*target, &textElement{[]byte(text)})
 
 		tagResult, err := tmpl.readTag(mayStandalone)
 		if err != nil {
@@ -351,7 +396,7 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 		}
 
 		if !tagResult.standalone {
-			section.elems = append(section.elems, &textElement{[]byte(padding)})
+			*target = append(*target, &textElement{[]byte(padding)})
 		}
 
 		tag := tagResult.tag
@@ -361,17 +406,18 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 			break
 		case '#', '^':
 			name := strings.TrimSpace(tag[1:])
-			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}}
-			err := tmpl.parseSection(&se)
+			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}, []interface{}{}, ""}
+			err := tmpl.parseSection(&se, tmpl.p)
 			if err != nil {
 				return err
 			}
-			section.elems = append(section.elems, &se)
+			*target = append(*target, &se)
 		case '/':
 			name := strings.TrimSpace(tag[1:])
 			if name != section.name {
 				return parseError{tmpl.curline, "interleaved closing tag: " + name}
 			}
+			section.text = tmpl.data[bodyStart:tagStart]
 			return nil
 		case '>':
 			name := strings.TrimSpace(tag[1:])
@@ -379,7 +425,7 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 			if err != nil {
 				return err
 			}
-			section.elems = append(section.elems, partial)
+			*target = append(*target, partial)
 		case '=':
 			if tag[len(tag)-1] != '=' {
 				return parseError{tmpl.curline, "Invalid meta tag"}
@@ -394,13 +440,18 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 			if tag[len(tag)-1] == '}' {
 				//use a raw tag
 				name := strings.TrimSpace(tag[1 : len(tag)-1])
-				section.elems = append(section.elems, &varElement{name, true})
+				*target = append(*target, &varElement{name, true, "", tmpl.curline})
 			}
 		case '&':
 			name := strings.TrimSpace(tag[1:])
-			section.elems = append(section.elems, &varElement{name, true})
+			*target = append(*target, &varElement{name, true, "", tmpl.curline})
 		default:
-			section.elems = append(section.elems, &varElement{tag, false})
+			if tag == "|" {
+				target = &section.altElems
+				break
+			}
+			name, fn := parseVarTag(tag)
+			*target = append(*target, &varElement{name, false, fn, tmpl.curline})
 		}
 	}
 }
@@ -437,8 +488,8 @@ func (tmpl *Template) parse() error {
 			break
 		case '#', '^':
 			name := strings.TrimSpace(tag[1:])
-			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}}
-			err := tmpl.parseSection(&se)
+			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}, []interface{}{}, ""}
+			err := tmpl.parseSection(&se, tmpl.p)
 			if err != nil {
 				return err
 			}
@@ -466,44 +517,71 @@ func (tmpl *Template) parse() error {
 			//use a raw tag
 			if tag[len(tag)-1] == '}' {
 				name := strings.TrimSpace(tag[1 : len(tag)-1])
-				tmpl.elems = append(tmpl.elems, &varElement{name, true})
+				tmpl.elems = append(tmpl.elems, &varElement{name, true, "", tmpl.curline})
 			}
 		case '&':
 			name := strings.TrimSpace(tag[1:])
-			tmpl.elems = append(tmpl.elems, &varElement{name, true})
+			tmpl.elems = append(tmpl.elems, &varElement{name, true, "", tmpl.curline})
 		default:
-			tmpl.elems = append(tmpl.elems, &varElement{tag, false})
+			name, fn := parseVarTag(tag)
+			tmpl.elems = append(tmpl.elems, &varElement{name, false, fn, tmpl.curline})
+		}
+	}
+}
+
+// methodCacheKey identifies a no-argument method lookup by receiver type
+// and method name, so the result of scanning a type's method set can be
+// memoized across lookups.
+type methodCacheKey struct {
+	typ  reflect.Type
+	name string
+}
+
+// methodIndex returns the index of typ's no-argument method named name,
+// or -1 if there is none, memoizing the result in tmpl.methodCache.
+func (tmpl *Template) methodIndex(typ reflect.Type, name string) int {
+	key := methodCacheKey{typ, name}
+	if v, ok := tmpl.methodCache.Load(key); ok {
+		return v.(int)
+	}
+	idx := -1
+	for i := 0; i < typ.NumMethod(); i++ {
+		m := typ.Method(i)
+		if m.Name == name && m.Type.NumIn() == 1 {
+			idx = i
+			break
 		}
 	}
+	tmpl.methodCache.Store(key, idx)
+	return idx
 }
 
-// Evaluate interfaces and pointers looking for a value that can look up the
-// name, via a struct field, method, or map key, and return the result of the
-// lookup.
-func lookup(contextChain []interface{}, name string, errMissing bool) (reflect.Value, error) {
+// lookup evaluates interfaces and pointers looking for a value that can
+// look up name, via a struct field, method, or map key, and returns the
+// result of the lookup, or an invalid reflect.Value if name cannot be
+// resolved. contextChain is searched from its last entry (the most
+// recently entered, innermost context) back to its first. Callers decide
+// how to treat a miss via the Template's MissingKeyMode.
+func (tmpl *Template) lookup(contextChain []interface{}, name string) (reflect.Value, error) {
 	// dot notation
 	if name != "." && strings.Contains(name, ".") {
 		parts := strings.SplitN(name, ".", 2)
 
-		v, err := lookup(contextChain, parts[0], errMissing)
-		if err != nil {
+		v, err := tmpl.lookup(contextChain, parts[0])
+		if err != nil || !v.IsValid() {
 			return v, err
 		}
-		return lookup([]interface{}{v}, parts[1], errMissing)
+		return tmpl.lookup([]interface{}{v}, parts[1])
 	}
 
 Outer:
-	for _, ctx := range contextChain {
-		v := ctx.(reflect.Value)
+	for i := len(contextChain) - 1; i >= 0; i-- {
+		v := contextChain[i].(reflect.Value)
 		for v.IsValid() {
 			typ := v.Type()
-			if n := v.Type().NumMethod(); n > 0 {
-				for i := 0; i < n; i++ {
-					m := typ.Method(i)
-					mtyp := m.Type
-					if m.Name == name && mtyp.NumIn() == 1 {
-						return v.Method(i).Call(nil)[0], nil
-					}
+			if typ.NumMethod() > 0 {
+				if mi := tmpl.methodIndex(typ, name); mi >= 0 {
+					return v.Method(mi).Call(nil)[0], nil
 				}
 			}
 			if name == "." {
@@ -531,9 +609,6 @@ Outer:
 			}
 		}
 	}
-	if errMissing {
-		return reflect.Value{}, fmt.Errorf("Missing variable %q", name)
-	}
 	return reflect.Value{}, nil
 }
 
@@ -571,13 +646,24 @@ loop:
 	return v
 }
 
-func (tmpl *Template) renderSection(section *sectionElement, contextChain []interface{}, buf io.Writer) error {
-	value, err := lookup(contextChain, section.name, false)
-	if err != nil {
-		return err
+// renderSection renders section against the current render stack
+// contextChain, whose last entry is the innermost (highest precedence)
+// context, and the current section path secPath, which is extended with
+// section.name for the duration of rendering the body (see renderError,
+// which reports secPath on failure). Entering the section body pushes
+// its context onto the end of contextChain and pops it off again
+// afterwards, so the backing array is reused across iterations instead
+// of being copied.
+func (tmpl *Template) renderSection(section *sectionElement, contextChain []interface{}, secPath []string, buf io.Writer) error {
+	value, _ := tmpl.lookup(contextChain, section.name)
+	if !section.inverted {
+		if fn := indirect(value); fn.IsValid() && fn.Kind() == reflect.Func {
+			return tmpl.renderSectionLambda(section, fn, contextChain, secPath, buf)
+		}
 	}
-	var context = contextChain[len(contextChain)-1].(reflect.Value)
+	var context = contextChain[0].(reflect.Value)
 	var contexts = []interface{}{}
+	isList := false
 	// if the value is nil, check if it's an inverted section
 	isEmpty := isEmpty(value)
 	if isEmpty && !section.inverted || !isEmpty && section.inverted {
@@ -586,10 +672,12 @@ func (tmpl *Template) renderSection(section *sectionElement, contextChain []inte
 		valueInd := indirect(value)
 		switch val := valueInd; val.Kind() {
 		case reflect.Slice:
+			isList = true
 			for i := 0; i < val.Len(); i++ {
 				contexts = append(contexts, val.Index(i))
 			}
 		case reflect.Array:
+			isList = true
 			for i := 0; i < val.Len(); i++ {
 				contexts = append(contexts, val.Index(i))
 			}
@@ -602,72 +690,191 @@ func (tmpl *Template) renderSection(section *sectionElement, contextChain []inte
 		contexts = append(contexts, context)
 	}
 
-	chain2 := make([]interface{}, len(contextChain)+1)
-	copy(chain2[1:], contextChain)
+	secPath = append(secPath, section.name)
 	//by default we execute the section
-	for _, ctx := range contexts {
-		chain2[0] = ctx
+	for i, ctx := range contexts {
+		if i > 0 && isList && len(section.altElems) > 0 {
+			for _, elem := range section.altElems {
+				if err := tmpl.renderElement(elem, contextChain, secPath, buf); err != nil {
+					return err
+				}
+			}
+		}
+		contextChain = append(contextChain, ctx)
 		for _, elem := range section.elems {
-			if err := tmpl.renderElement(elem, chain2, buf); err != nil {
+			if err := tmpl.renderElement(elem, contextChain, secPath, buf); err != nil {
+				contextChain = contextChain[:len(contextChain)-1]
 				return err
 			}
 		}
+		contextChain = contextChain[:len(contextChain)-1]
 	}
 	return nil
 }
 
-func (tmpl *Template) renderElement(element interface{}, contextChain []interface{}, buf io.Writer) error {
+// renderSectionLambda implements the Mustache Lambdas module: fn is called
+// with the section's unrendered inner template text, and the string it
+// returns is parsed as a mustache template and rendered against the current
+// context chain.
+func (tmpl *Template) renderSectionLambda(section *sectionElement, fn reflect.Value, contextChain []interface{}, secPath []string, buf io.Writer) error {
+	results := fn.Call([]reflect.Value{reflect.ValueOf(section.text)})
+	if len(results) == 0 {
+		return fmt.Errorf("mustache: lambda %q returned no value", section.name)
+	}
+	if len(results) > 1 {
+		if errVal, ok := results[1].Interface().(error); ok && errVal != nil {
+			return errVal
+		}
+	}
+	sub, err := ParseStringPartials(fmt.Sprint(results[0].Interface()), tmpl.partial)
+	if err != nil {
+		return err
+	}
+	sub.funcs = tmpl.funcs
+	sub.missing = tmpl.missing
+	sub.cache = tmpl.cache
+	return sub.renderTemplate(contextChain, append(secPath, section.name), buf)
+}
+
+// renderVarLambda implements the Mustache Lambdas module for
+// interpolation tags: fn is called with no arguments, and the string it
+// returns is parsed as a mustache template and rendered against the
+// current context chain, producing the text that is then formatted or
+// escaped like any other variable value.
+func (tmpl *Template) renderVarLambda(name string, fn reflect.Value, contextChain []interface{}, secPath []string) (string, error) {
+	results := fn.Call(nil)
+	if len(results) == 0 {
+		return "", fmt.Errorf("mustache: lambda %q returned no value", name)
+	}
+	if len(results) > 1 {
+		if errVal, ok := results[1].Interface().(error); ok && errVal != nil {
+			return "", errVal
+		}
+	}
+	sub, err := ParseStringPartials(fmt.Sprint(results[0].Interface()), tmpl.partial)
+	if err != nil {
+		return "", err
+	}
+	sub.funcs = tmpl.funcs
+	sub.missing = tmpl.missing
+	sub.cache = tmpl.cache
+	var out bytes.Buffer
+	if err := sub.renderTemplate(contextChain, secPath, &out); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (tmpl *Template) renderElement(element interface{}, contextChain []interface{}, secPath []string, buf io.Writer) error {
 	switch elem := element.(type) {
 	case *textElement:
 		_, err := buf.Write(elem.text)
 		return err
 	case *varElement:
-		val, err := lookup(contextChain, elem.name, tmpl.errmiss)
-		if err != nil {
-			return err
+		val, _ := tmpl.lookup(contextChain, elem.name)
+		if !val.IsValid() {
+			resolved, err := tmpl.missingKeyMode().resolve(elem.name, contextChain)
+			if err != nil {
+				return tmpl.renderError(elem.name, elem.line, secPath, err)
+			}
+			val = resolved
+			if !val.IsValid() {
+				break
+			}
 		}
-		if val.IsValid() {
-			if elem.raw {
-				fmt.Fprint(buf, val.Interface())
-			} else {
-				s := fmt.Sprint(val.Interface())
-				template.HTMLEscape(buf, []byte(s))
+		if lambda := indirect(val); lambda.IsValid() && lambda.Kind() == reflect.Func {
+			s, err := tmpl.renderVarLambda(elem.name, lambda, contextChain, secPath)
+			if err != nil {
+				return tmpl.renderError(elem.name, elem.line, secPath, err)
 			}
+			val = reflect.ValueOf(s)
+		}
+		if elem.raw {
+			fmt.Fprint(buf, val.Interface())
+			break
+		}
+		fn, ok := tmpl.funcs[elem.fn]
+		if !ok {
+			return tmpl.renderError(elem.name, elem.line, secPath, fmt.Errorf("unknown formatter %q", elem.fn))
+		}
+		s, err := fn(val.Interface())
+		if err != nil {
+			return tmpl.renderError(elem.name, elem.line, secPath, err)
+		}
+		if _, err := io.WriteString(buf, s); err != nil {
+			return err
 		}
 	case *sectionElement:
-		if err := tmpl.renderSection(elem, contextChain, buf); err != nil {
+		if err := tmpl.renderSection(elem, contextChain, secPath, buf); err != nil {
 			return err
 		}
 	case *partialElement:
-		partial, err := getPartials(elem.prov, elem.name, elem.indent)
+		partial, err := tmpl.renderPartialCached(elem)
 		if err != nil {
 			return err
 		}
-		if err := partial.renderTemplate(contextChain, buf); err != nil {
+		if err := partial.renderTemplate(contextChain, secPath, buf); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (tmpl *Template) renderTemplate(contextChain []interface{}, buf io.Writer) error {
+func (tmpl *Template) renderTemplate(contextChain []interface{}, secPath []string, buf io.Writer) error {
 	for _, elem := range tmpl.elems {
-		if err := tmpl.renderElement(elem, contextChain, buf); err != nil {
+		if err := tmpl.renderElement(elem, contextChain, secPath, buf); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// renderState is a pooled, reusable context-chain stack, so that repeated
+// FRender calls don't each allocate a fresh backing array for the initial
+// chain. Its secPath starts every render empty with no capacity kept, and
+// renderSection extends the section path on its own copy.
+type renderState struct {
+	chain   []interface{}
+	secPath []string
+}
+
+var renderStatePool = sync.Pool{
+	New: func() interface{} { return new(renderState) },
+}
+
+// bufWriterPool pools the small buffered writer FRender wraps its
+// io.Writer argument in, amortizing the per-render allocation of the
+// buffer across calls.
+var bufWriterPool = sync.Pool{
+	New: func() interface{} { return bufio.NewWriterSize(io.Discard, 4096) },
+}
+
 // FRender uses the given data source - generally a map or struct - to render
 // the compiled template to an io.Writer.
 func (tmpl *Template) FRender(out io.Writer, context ...interface{}) error {
-	var contextChain []interface{}
-	for _, c := range context {
-		val := reflect.ValueOf(c)
-		contextChain = append(contextChain, val)
+	rs := renderStatePool.Get().(*renderState)
+	rs.chain = rs.chain[:0]
+	rs.secPath = rs.secPath[:0]
+	// context[0] must end up with the highest lookup precedence, i.e. on
+	// top of the stack, so push it last.
+	for i := len(context) - 1; i >= 0; i-- {
+		rs.chain = append(rs.chain, reflect.ValueOf(context[i]))
+	}
+
+	bw := bufWriterPool.Get().(*bufio.Writer)
+	bw.Reset(out)
+
+	err := tmpl.renderTemplate(rs.chain, rs.secPath, bw)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
 	}
-	return tmpl.renderTemplate(contextChain, out)
+
+	bw.Reset(io.Discard)
+	bufWriterPool.Put(bw)
+	rs.chain = rs.chain[:0]
+	rs.secPath = rs.secPath[:0]
+	renderStatePool.Put(rs)
+	return err
 }
 
 // Render uses the given data source - generally a map or struct - to render
@@ -718,7 +925,7 @@ func ParseStringPartials(data string, partials PartialProvider) (*Template, erro
 	if partials == nil {
 		partials = &EmptyProvider
 	}
-	tmpl := Template{data, "{{", "}}", 0, 1, []interface{}{}, partials, false}
+	tmpl := Template{data, "{{", "}}", 0, 1, []interface{}{}, partials, nil, FuncMap{"": defaultFormatter}, nil, sync.Map{}}
 	err := tmpl.parse()
 	if err != nil {
 		return nil, err
@@ -726,8 +933,109 @@ func ParseStringPartials(data string, partials PartialProvider) (*Template, erro
 	return &tmpl, err
 }
 
+// MissingKeyMode controls how a Template resolves a variable tag whose
+// name cannot be found anywhere in the current context chain. Use one of
+// the package-level MissingKey* values, or build a custom one with
+// MissingKeyDefault. A Template treats a nil MissingKeyMode as
+// MissingKeyInvalid.
+type MissingKeyMode interface {
+	resolve(name string, contextChain []interface{}) (reflect.Value, error)
+}
+
+type missingKeyFunc func(name string, contextChain []interface{}) (reflect.Value, error)
+
+func (f missingKeyFunc) resolve(name string, contextChain []interface{}) (reflect.Value, error) {
+	return f(name, contextChain)
+}
+
+// MissingKeyInvalid renders a missing variable as empty. It is the
+// default mode.
+var MissingKeyInvalid MissingKeyMode = missingKeyFunc(
+	func(name string, contextChain []interface{}) (reflect.Value, error) {
+		return reflect.Value{}, nil
+	},
+)
+
+// MissingKeyError causes rendering to fail with a *RenderError as soon
+// as a variable tag cannot be resolved.
+var MissingKeyError MissingKeyMode = missingKeyFunc(
+	func(name string, contextChain []interface{}) (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("missing variable %q", name)
+	},
+)
+
+// MissingKeyDefault builds a MissingKeyMode that calls fn to resolve a
+// variable tag that could not be found by the normal lookup, e.g. to
+// substitute a placeholder value instead of erroring or rendering
+// nothing. A nil, nil result is treated the same as MissingKeyInvalid.
+func MissingKeyDefault(fn func(name string, contextChain []interface{}) (interface{}, error)) MissingKeyMode {
+	return missingKeyFunc(
+		func(name string, contextChain []interface{}) (reflect.Value, error) {
+			v, err := fn(name, contextChain)
+			if err != nil || v == nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(v), nil
+		},
+	)
+}
+
+// missingKeyMode returns tmpl.missing, defaulting to MissingKeyInvalid.
+func (tmpl *Template) missingKeyMode() MissingKeyMode {
+	if tmpl.missing != nil {
+		return tmpl.missing
+	}
+	return MissingKeyInvalid
+}
+
+// RenderError reports a rendering failure together with the tag and
+// location in the template source that caused it.
+type RenderError struct {
+	Name    string   // name of the variable or formatter tag involved
+	Line    int      // source line of the tag, or 0 if unknown
+	Section []string // enclosing section names, outermost first
+	Err     error
+}
+
+func (e *RenderError) Error() string {
+	if len(e.Section) == 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: in section %q: %v", e.Line, strings.Join(e.Section, "."), e.Err)
+}
+
+func (e *RenderError) Unwrap() error { return e.Err }
+
+func (tmpl *Template) renderError(name string, line int, secPath []string, err error) error {
+	return &RenderError{Name: name, Line: line, Section: secPath, Err: err}
+}
+
 // SetErrorOnMissing will produce an error is a variable is not found.
-func (tmpl *Template) SetErrorOnMissing() { tmpl.errmiss = true }
+func (tmpl *Template) SetErrorOnMissing() { tmpl.missing = MissingKeyError }
+
+// SetMissingKeyMode controls how tmpl resolves a variable tag that
+// cannot be found in the current context chain; see MissingKeyMode.
+func (tmpl *Template) SetMissingKeyMode(mode MissingKeyMode) { tmpl.missing = mode }
+
+// SetFuncs registers formatter/lambda functions that can be invoked from
+// variable tags using the "{{ name | fn }}" or "{{fn name}}" syntax.
+// Registering a function under the empty name "" overrides the default
+// formatter used for plain variable tags such as "{{name}}".
+func (tmpl *Template) SetFuncs(funcs FuncMap) {
+	if tmpl.funcs == nil {
+		tmpl.funcs = FuncMap{}
+	}
+	for name, fn := range funcs {
+		tmpl.funcs[name] = fn
+	}
+}
+
+// WithFuncs registers funcs (see SetFuncs) and returns tmpl, to allow
+// chaining after ParseString(Partials).
+func (tmpl *Template) WithFuncs(funcs FuncMap) *Template {
+	tmpl.SetFuncs(funcs)
+	return tmpl
+}
 
 // PartialProvider comprises the behaviors required of a struct to be able to
 // provide partials to the mustache rendering engine.
@@ -765,6 +1073,16 @@ func (sp *StaticProvider) Get(name string) (string, error) {
 	return "", &ErrPartialNotFound{name}
 }
 
+// Set stores (or replaces) the partial named name. If a cache-enabled
+// Template may already have a parsed copy of it, call
+// Template.InvalidatePartial(name) afterwards so the change takes effect.
+func (sp *StaticProvider) Set(name, data string) {
+	if sp.Partials == nil {
+		sp.Partials = map[string]string{}
+	}
+	sp.Partials[name] = data
+}
+
 // emptyProvider will always returns an empty string.
 type emptyProvider struct{}
 
@@ -774,6 +1092,141 @@ func (ep *emptyProvider) Get(name string) (string, error) { return "", nil }
 // EmptyProvider is a partial provider that will always return an empty string.
 var EmptyProvider emptyProvider
 
+// partialCacheKey identifies a parsed partial by both its name and the
+// indent it was rendered with, since a differently indented occurrence
+// of the same partial produces a different parsed source.
+type partialCacheKey struct {
+	name   string
+	indent string
+}
+
+// PartialCacheStats reports hit/miss counts for a Template's partial
+// cache, for observability. It is the zero value if caching was never
+// enabled via EnableCache.
+type PartialCacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// partialCache is a concurrent-safe cache of parsed partial templates,
+// shared by a root Template and every partial template reachable from it.
+type partialCache struct {
+	mu           sync.RWMutex
+	m            map[partialCacheKey]*Template
+	hits, misses int64
+}
+
+func newPartialCache() *partialCache {
+	return &partialCache{m: map[partialCacheKey]*Template{}}
+}
+
+func (c *partialCache) get(key partialCacheKey) (*Template, bool) {
+	c.mu.RLock()
+	tmpl, ok := c.m[key]
+	c.mu.RUnlock()
+	if ok {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+	return tmpl, ok
+}
+
+func (c *partialCache) put(key partialCacheKey, tmpl *Template) {
+	c.mu.Lock()
+	c.m[key] = tmpl
+	c.mu.Unlock()
+}
+
+func (c *partialCache) invalidate(name string) {
+	c.mu.Lock()
+	for key := range c.m {
+		if key.name == name {
+			delete(c.m, key)
+		}
+	}
+	c.mu.Unlock()
+}
+
+func (c *partialCache) stats() PartialCacheStats {
+	return PartialCacheStats{
+		Hits:   atomic.LoadInt64(&c.hits),
+		Misses: atomic.LoadInt64(&c.misses),
+	}
+}
+
+// EnableCache turns on partial-template caching for tmpl: parsed partial
+// trees are cached by (name, indent) and reused by Render/FRender on
+// subsequent renders, and by repeated occurrences of the same "{{>foo}}"
+// tag, instead of being re-parsed every time. It returns tmpl for
+// chaining.
+func (tmpl *Template) EnableCache() *Template {
+	tmpl.cache = newPartialCache()
+	return tmpl
+}
+
+// CacheStats reports the partial cache's hit/miss counts. It returns the
+// zero value if caching has not been enabled via EnableCache.
+func (tmpl *Template) CacheStats() PartialCacheStats {
+	if tmpl.cache == nil {
+		return PartialCacheStats{}
+	}
+	return tmpl.cache.stats()
+}
+
+// InvalidatePartial drops any cached parsed tree for the partial named
+// name, so the next render re-reads and re-parses it from the
+// PartialProvider. This is the hook to call after mutating the
+// underlying source of a partial, e.g. via StaticProvider.Set or
+// FSProvider.Invalidate.
+func (tmpl *Template) InvalidatePartial(name string) {
+	if tmpl.cache != nil {
+		tmpl.cache.invalidate(name)
+	}
+}
+
+// renderPartialCached resolves elem to a parsed Template, reusing a
+// cached parse keyed by (name, indent) when tmpl.cache is set.
+func (tmpl *Template) renderPartialCached(elem *partialElement) (*Template, error) {
+	key := partialCacheKey{elem.name, elem.indent}
+	if tmpl.cache != nil {
+		if cached, ok := tmpl.cache.get(key); ok {
+			return cached, nil
+		}
+	}
+
+	parsed, err := getPartials(elem.prov, elem.name, elem.indent)
+	if err != nil {
+		return nil, err
+	}
+	parsed.cache = tmpl.cache
+	parsed.funcs = tmpl.funcs
+	parsed.missing = tmpl.missing
+
+	if tmpl.cache != nil {
+		tmpl.cache.put(key, parsed)
+	}
+	return parsed, nil
+}
+
+// RenderCached behaves like Render, but first enables partial caching
+// (see EnableCache) if it isn't already, so that repeated partials reuse
+// a single parsed tree.
+func (tmpl *Template) RenderCached(context ...interface{}) (string, error) {
+	if tmpl.cache == nil {
+		tmpl.EnableCache()
+	}
+	return tmpl.Render(context...)
+}
+
+// FRenderCached is the io.Writer counterpart of RenderCached.
+func (tmpl *Template) FRenderCached(out io.Writer, context ...interface{}) error {
+	if tmpl.cache == nil {
+		tmpl.EnableCache()
+	}
+	return tmpl.FRender(out, context...)
+}
+
 var nonEmptyLine = regexp.MustCompile(`(?m:^(.+)$)`)
 
 func getPartials(partials PartialProvider, name, indent string) (*Template, error) {
@@ -0,0 +1,101 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2020 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import (
+	"io"
+	"testing"
+)
+
+// BenchmarkDeepLoop renders a section iterating a moderately large slice,
+// exercising the pooled context-chain stack across many push/pop cycles.
+func BenchmarkDeepLoop(b *testing.B) {
+	tmpl, err := ParseString(`{{#items}}{{.}}{{|}},{{/items}}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = "x"
+	}
+	data := map[string]interface{}{"items": items}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tmpl.FRender(io.Discard, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDeepDottedLookup renders a deeply nested dotted variable
+// reference, exercising lookup's dot-notation recursion through nested
+// maps. The maps have no methods, so no method lookup takes place.
+func BenchmarkDeepDottedLookup(b *testing.B) {
+	tmpl, err := ParseString(`{{a.b.c.d.e.f.g.h}}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{
+				"c": map[string]interface{}{
+					"d": map[string]interface{}{
+						"e": map[string]interface{}{
+							"f": map[string]interface{}{
+								"g": map[string]interface{}{
+									"h": "leaf",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tmpl.FRender(io.Discard, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPartialHeavy renders a template that repeatedly invokes the
+// same partial, exercising renderPartialCached with caching enabled.
+func BenchmarkPartialHeavy(b *testing.B) {
+	partials := &StaticProvider{Partials: map[string]string{
+		"row": "[{{name}}]",
+	}}
+	tmpl, err := ParseStringPartials(
+		`{{#items}}{{>row}}{{/items}}`, partials)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tmpl.EnableCache()
+
+	items := make([]map[string]interface{}, 100)
+	for i := range items {
+		items[i] = map[string]interface{}{"name": "x"}
+	}
+	data := map[string]interface{}{"items": items}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tmpl.FRender(io.Discard, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
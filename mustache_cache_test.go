@@ -0,0 +1,112 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2020 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import "testing"
+
+func TestCacheStatsZeroBeforeEnableCache(t *testing.T) {
+	tmpl, err := ParseString(`{{>row}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	if stats := tmpl.CacheStats(); stats != (PartialCacheStats{}) {
+		t.Errorf("CacheStats before EnableCache = %+v, want zero value", stats)
+	}
+}
+
+func TestCacheStatsHitsAndMisses(t *testing.T) {
+	partials := &StaticProvider{Partials: map[string]string{"row": "[{{name}}]"}}
+	tmpl, err := ParseStringPartials(`{{>row}}{{>row}}`, partials)
+	if err != nil {
+		t.Fatalf("ParseStringPartials: %v", err)
+	}
+	tmpl.EnableCache()
+
+	if _, err := tmpl.Render(map[string]interface{}{"name": "a"}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	// Both "{{>row}}" occurrences share the same (name, indent) key, so
+	// the first is a miss and the second a hit.
+	if stats := tmpl.CacheStats(); stats.Misses != 1 || stats.Hits != 1 {
+		t.Errorf("after first render: stats = %+v, want {Hits:1 Misses:1}", stats)
+	}
+
+	if _, err := tmpl.Render(map[string]interface{}{"name": "b"}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	// A second render of the same Template reuses the already-cached
+	// parse, so both occurrences are now hits.
+	if stats := tmpl.CacheStats(); stats.Misses != 1 || stats.Hits != 3 {
+		t.Errorf("after second render: stats = %+v, want {Hits:3 Misses:1}", stats)
+	}
+}
+
+func TestCacheStatsDistinctIndentsAreDistinctEntries(t *testing.T) {
+	partials := &StaticProvider{Partials: map[string]string{"row": "[x]"}}
+	tmpl, err := ParseStringPartials("{{>row}}\n  {{>row}}\n", partials)
+	if err != nil {
+		t.Fatalf("ParseStringPartials: %v", err)
+	}
+	tmpl.EnableCache()
+
+	if _, err := tmpl.Render(map[string]interface{}{}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	// The unindented and indented occurrences are different cache keys,
+	// so both are misses on first use.
+	if stats := tmpl.CacheStats(); stats.Misses != 2 || stats.Hits != 0 {
+		t.Errorf("stats = %+v, want {Hits:0 Misses:2}", stats)
+	}
+}
+
+func TestRenderCachedEnablesCaching(t *testing.T) {
+	partials := &StaticProvider{Partials: map[string]string{"row": "[{{name}}]"}}
+	tmpl, err := ParseStringPartials(`{{>row}}`, partials)
+	if err != nil {
+		t.Fatalf("ParseStringPartials: %v", err)
+	}
+	if stats := tmpl.CacheStats(); stats != (PartialCacheStats{}) {
+		t.Fatalf("CacheStats before RenderCached = %+v, want zero value", stats)
+	}
+	out, err := tmpl.RenderCached(map[string]interface{}{"name": "x"})
+	if err != nil {
+		t.Fatalf("RenderCached: %v", err)
+	}
+	if out != "[x]" {
+		t.Errorf("got %q, want %q", out, "[x]")
+	}
+	if stats := tmpl.CacheStats(); stats.Misses != 1 {
+		t.Errorf("stats = %+v, want a miss recorded", stats)
+	}
+}
+
+func TestInvalidatePartialResetsCacheEntry(t *testing.T) {
+	partials := &StaticProvider{Partials: map[string]string{"row": "[{{name}}]"}}
+	tmpl, err := ParseStringPartials(`{{>row}}`, partials)
+	if err != nil {
+		t.Fatalf("ParseStringPartials: %v", err)
+	}
+	tmpl.EnableCache()
+	if _, err := tmpl.Render(map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	partials.Set("row", "<{{name}}>")
+	tmpl.InvalidatePartial("row")
+
+	out, err := tmpl.Render(map[string]interface{}{"name": "y"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "<y>"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,152 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2020 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMissingKeyInvalidRendersEmpty(t *testing.T) {
+	tmpl, err := ParseString(`[{{missing}}]`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	tmpl.SetMissingKeyMode(MissingKeyInvalid)
+	out, err := tmpl.Render(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if out != "[]" {
+		t.Errorf("got %q, want %q", out, "[]")
+	}
+}
+
+func TestMissingKeyErrorStopsRendering(t *testing.T) {
+	tmpl, err := ParseString(`[{{missing}}]`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	tmpl.SetMissingKeyMode(MissingKeyError)
+	_, err = tmpl.Render(map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	var rerr *RenderError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("got %v, want *RenderError", err)
+	}
+}
+
+func TestSetErrorOnMissingSetsErrorMode(t *testing.T) {
+	tmpl, err := ParseString(`{{missing}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	tmpl.SetErrorOnMissing()
+	_, err = tmpl.Render(map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestMissingKeyDefaultSubstitutesValue(t *testing.T) {
+	tmpl, err := ParseString(`[{{missing}}]`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	tmpl.SetMissingKeyMode(MissingKeyDefault(
+		func(name string, chain []interface{}) (interface{}, error) {
+			return "fallback-" + name, nil
+		},
+	))
+	out, err := tmpl.Render(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "[fallback-missing]"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestMissingKeyDefaultCanStillError(t *testing.T) {
+	tmpl, err := ParseString(`{{missing}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	sentinel := errors.New("boom")
+	tmpl.SetMissingKeyMode(MissingKeyDefault(
+		func(name string, chain []interface{}) (interface{}, error) {
+			return nil, sentinel
+		},
+	))
+	_, err = tmpl.Render(map[string]interface{}{})
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("got %v, want wrapped %v", err, sentinel)
+	}
+}
+
+func TestRenderErrorReportsLineAndSectionPath(t *testing.T) {
+	tmpl, err := ParseString("{{#user}}\n{{#posts}}\n{{title}}\n{{/posts}}\n{{/user}}")
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	tmpl.SetErrorOnMissing()
+	_, err = tmpl.Render(map[string]interface{}{
+		"user": map[string]interface{}{
+			"posts": map[string]interface{}{},
+		},
+	})
+	var rerr *RenderError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("got %v, want *RenderError", err)
+	}
+	if rerr.Name != "title" {
+		t.Errorf("Name = %q, want %q", rerr.Name, "title")
+	}
+	if rerr.Line != 3 {
+		t.Errorf("Line = %d, want %d", rerr.Line, 3)
+	}
+	if want := []string{"user", "posts"}; !equalStrings(rerr.Section, want) {
+		t.Errorf("Section = %v, want %v", rerr.Section, want)
+	}
+	if got, want := rerr.Error(), `line 3: in section "user.posts": missing variable "title"`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderErrorNoSectionFormatting(t *testing.T) {
+	tmpl, err := ParseString(`{{missing}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	tmpl.SetErrorOnMissing()
+	_, err = tmpl.Render(map[string]interface{}{})
+	var rerr *RenderError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("got %v, want *RenderError", err)
+	}
+	if got, want := rerr.Error(), `line 1: missing variable "missing"`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2020 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import "testing"
+
+func TestFuncMapFormatterDispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmplText string
+	}{
+		{"pipe form", `{{ name | upper }}`},
+		{"prefix form", `{{upper name}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseString(tt.tmplText)
+			if err != nil {
+				t.Fatalf("ParseString: %v", err)
+			}
+			tmpl.SetFuncs(FuncMap{
+				"upper": func(v interface{}) (string, error) {
+					s := v.(string)
+					out := make([]byte, len(s))
+					for i := 0; i < len(s); i++ {
+						c := s[i]
+						if c >= 'a' && c <= 'z' {
+							c -= 'a' - 'A'
+						}
+						out[i] = c
+					}
+					return string(out), nil
+				},
+			})
+			out, err := tmpl.Render(map[string]interface{}{"name": "bob"})
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if out != "BOB" {
+				t.Errorf("got %q, want %q", out, "BOB")
+			}
+		})
+	}
+}
+
+func TestFuncMapUnknownFormatter(t *testing.T) {
+	tmpl, err := ParseString(`{{ name | nope }}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	_, err = tmpl.Render(map[string]interface{}{"name": "bob"})
+	if err == nil {
+		t.Fatal("expected an error for an unregistered formatter")
+	}
+	if got, want := err.Error(), `line 1: unknown formatter "nope"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDefaultFormatterEscapesHTML(t *testing.T) {
+	tmpl, err := ParseString(`{{name}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	out, err := tmpl.Render(map[string]interface{}{"name": `<b>"x"</b>`})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "&lt;b&gt;&#34;x&#34;&lt;/b&gt;"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestWithFuncsChains(t *testing.T) {
+	tmpl, err := ParseString(`{{fn name}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	tmpl = tmpl.WithFuncs(FuncMap{
+		"fn": func(v interface{}) (string, error) { return "[" + v.(string) + "]", nil },
+	})
+	out, err := tmpl.Render(map[string]interface{}{"name": "x"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if out != "[x]" {
+		t.Errorf("got %q, want %q", out, "[x]")
+	}
+}
+
+func TestSectionLambdaReceivesRawInnerText(t *testing.T) {
+	tmpl, err := ParseString(`{{#wrap}}a{{name}}b{{/wrap}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	var gotText string
+	out, err := tmpl.Render(map[string]interface{}{
+		"name": "X",
+		"wrap": func(text string) (string, error) {
+			gotText = text
+			return "<" + text + ">", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "a{{name}}b"; gotText != want {
+		t.Errorf("lambda saw %q, want %q", gotText, want)
+	}
+	if want := "a" + "X" + "b"; out != "<"+want+">" {
+		t.Errorf("got %q, want %q", out, "<"+want+">")
+	}
+}
+
+func TestVarLambdaOutputIsReescaped(t *testing.T) {
+	tmpl, err := ParseString(`{{lambda}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	out, err := tmpl.Render(map[string]interface{}{
+		"lambda": func() string { return "<b>" },
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "&lt;b&gt;"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,87 @@
+//-----------------------------------------------------------------------------
+// Copyright (c) 2020 Detlef Stern
+//
+// This file is part of zettelstore.
+//
+// Zettelstore is licensed under the latest version of the EUPL (European Union
+// Public License). Please see file LICENSE.txt for your rights and obligations
+// under this license.
+//-----------------------------------------------------------------------------
+
+package template
+
+import "testing"
+
+func renderSeparator(t *testing.T, tmplText string, items interface{}) string {
+	t.Helper()
+	tmpl, err := ParseString(tmplText)
+	if err != nil {
+		t.Fatalf("ParseString(%q): %v", tmplText, err)
+	}
+	out, err := tmpl.Render(map[string]interface{}{"items": items})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return out
+}
+
+func TestSeparatorEmptySection(t *testing.T) {
+	got := renderSeparator(t, `{{#items}}{{.}}{{|}},{{/items}}`, []string{})
+	if got != "" {
+		t.Errorf("got %q, want %q", got, "")
+	}
+}
+
+func TestSeparatorSingleElement(t *testing.T) {
+	got := renderSeparator(t, `{{#items}}{{.}}{{|}},{{/items}}`, []string{"a"})
+	if got != "a" {
+		t.Errorf("got %q, want %q", got, "a")
+	}
+}
+
+func TestSeparatorMultipleElements(t *testing.T) {
+	got := renderSeparator(t, `{{#items}}{{.}}{{|}}, {{/items}}`, []string{"a", "b", "c"})
+	if want := "a, b, c"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSeparatorNestedSection(t *testing.T) {
+	type row struct {
+		Name string
+		Tags []string
+	}
+	rows := []row{
+		{Name: "x", Tags: []string{"1", "2"}},
+		{Name: "y", Tags: []string{"3"}},
+	}
+	got := renderSeparator(t,
+		`{{#items}}{{Name}}:{{#Tags}}{{.}}{{|}}-{{/Tags}}{{|}}; {{/items}}`, rows)
+	if want := "x:1-2; y:3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSeparatorStandaloneWhitespace(t *testing.T) {
+	tmplText := "{{#items}}\n{{.}}\n{{|}}\n---\n{{/items}}\n"
+	got := renderSeparator(t, tmplText, []string{"a", "b"})
+	if want := "a\n---\nb\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSeparatorMapAndStructSkipped(t *testing.T) {
+	tmpl, err := ParseString(`{{#items}}{{name}}{{|}},{{/items}}`)
+	if err != nil {
+		t.Fatalf("ParseString: %v", err)
+	}
+	out, err := tmpl.Render(map[string]interface{}{
+		"items": map[string]interface{}{"name": "solo"},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if out != "solo" {
+		t.Errorf("got %q, want %q", out, "solo")
+	}
+}